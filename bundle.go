@@ -0,0 +1,204 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kbncontent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stretchr/objx"
+)
+
+// Ref identifies a saved object by type and ID.
+type Ref struct {
+	Type string
+	ID   string
+}
+
+// Bundle is a set of saved objects considered together, such as the objects
+// making up a single NDJSON file produced by the Saved Objects export API. It
+// can be used to validate that the bundle is self-contained before shipping
+// or importing it elsewhere.
+type Bundle struct {
+	Objects []map[string]interface{}
+}
+
+// NewBundle returns a Bundle over the given saved objects.
+func NewBundle(objects []map[string]interface{}) Bundle {
+	return Bundle{Objects: objects}
+}
+
+func refOf(obj map[string]interface{}) Ref {
+	m := objx.Map(obj)
+	return Ref{Type: m.Get("type").Str(), ID: m.Get("id").Str()}
+}
+
+// Graph returns, for every object in the bundle, the set of other objects it
+// depends on: its saved-object references[] plus any index-pattern references
+// embedded directly in by-value dashboard panels.
+func (b Bundle) Graph() (map[Ref][]Ref, error) {
+	graph := make(map[Ref][]Ref, len(b.Objects))
+	for _, obj := range b.Objects {
+		ref := refOf(obj)
+		refs, err := b.referencesOf(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect references for %s %q: %w", ref.Type, ref.ID, err)
+		}
+		graph[ref] = refs
+	}
+	return graph, nil
+}
+
+func (b Bundle) referencesOf(obj map[string]interface{}) ([]Ref, error) {
+	var refs []Ref
+
+	if refsVal, ok := obj["references"]; ok && refsVal != nil {
+		topLevel, err := toReferenceSlice(refsVal)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read references: %w", err)
+		}
+		for _, r := range topLevel {
+			refs = appendRefIfMissing(refs, Ref{Type: r.Type, ID: r.ID})
+		}
+	}
+
+	soType, _ := obj["type"].(string)
+	if soType != "dashboard" {
+		return refs, nil
+	}
+
+	// By-reference panel IDs (identifiable via GetByReferencePanelIDs) are
+	// already present above: they're just the entries in the dashboard's own
+	// references[] whose name contains "panel_", and the loop over
+	// toReferenceSlice picks those up, with their real Type, along with
+	// everything else the dashboard references.
+	panels, err := DescribeByValueDashboardPanels(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan by-value panels: %w", err)
+	}
+	for _, panel := range panels {
+		for _, ref := range panelIndexPatternRefs(panel) {
+			refs = appendRefIfMissing(refs, ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// panelIndexPatternRefs extracts index-pattern references embedded directly
+// inside a by-value panel. These aren't covered by the dashboard's top-level
+// references[] because the panel itself, not the dashboard, owns them.
+func panelIndexPatternRefs(panel VisualizationDescriptor) []Ref {
+	m := objx.Map(panel.Doc)
+	var refs []Ref
+
+	if embedded := m.Get("embeddableConfig.attributes.references"); embedded.IsObjxMapSlice() {
+		for _, ref := range embedded.ObjxMapSlice() {
+			name := ref.Get("name").Str()
+			if strings.Contains(name, "indexRefName") || strings.Contains(name, "indexPatternRefName") || strings.Contains(name, "index-pattern") {
+				refs = append(refs, Ref{Type: ref.Get("type").Str(), ID: ref.Get("id").Str()})
+			}
+		}
+	}
+
+	if index := m.Get("embeddableConfig.savedVis.data.searchSource.index"); index.IsStr() && index.Str() != "" {
+		refs = append(refs, Ref{Type: "index-pattern", ID: index.Str()})
+	}
+
+	return refs
+}
+
+func appendRefIfMissing(refs []Ref, ref Ref) []Ref {
+	for _, r := range refs {
+		if r == ref {
+			return refs
+		}
+	}
+	return append(refs, ref)
+}
+
+// MissingReferences returns the references found in the bundle's graph that
+// point at an object not present anywhere in the bundle.
+func (b Bundle) MissingReferences() ([]Ref, error) {
+	graph, err := b.Graph()
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[Ref]bool, len(b.Objects))
+	for _, obj := range b.Objects {
+		present[refOf(obj)] = true
+	}
+
+	var missing []Ref
+	seen := make(map[Ref]bool)
+	for _, refs := range graph {
+		for _, ref := range refs {
+			if present[ref] || seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			missing = append(missing, ref)
+		}
+	}
+	return missing, nil
+}
+
+// TopologicalOrder returns the bundle's objects ordered so that every object
+// appears after everything it references, suitable for feeding to Kibana's
+// saved objects import API in a single pass. References to objects outside
+// the bundle are ignored here; use MissingReferences to find those. It
+// returns an error if the bundle's references form a cycle.
+func (b Bundle) TopologicalOrder() ([]map[string]interface{}, error) {
+	graph, err := b.Graph()
+	if err != nil {
+		return nil, err
+	}
+
+	byRef := make(map[Ref]map[string]interface{}, len(b.Objects))
+	for _, obj := range b.Objects {
+		byRef[refOf(obj)] = obj
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[Ref]int, len(b.Objects))
+	var order []map[string]interface{}
+
+	var visit func(ref Ref) error
+	visit = func(ref Ref) error {
+		switch state[ref] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("reference cycle detected at %s %q", ref.Type, ref.ID)
+		}
+		state[ref] = visiting
+
+		for _, dep := range graph[ref] {
+			if _, ok := byRef[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[ref] = visited
+		order = append(order, byRef[ref])
+		return nil
+	}
+
+	for _, obj := range b.Objects {
+		if err := visit(refOf(obj)); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,152 @@
+package kbncontent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleMissingReferencesDoesNotDuplicatePanelRefs(t *testing.T) {
+	dashboard := map[string]interface{}{
+		"id":   "dash-1",
+		"type": "dashboard",
+		"attributes": map[string]interface{}{
+			"panelsJSON": `[]`,
+		},
+		"references": []interface{}{
+			map[string]interface{}{"id": "missing-index", "type": "index-pattern", "name": "panel_0"},
+		},
+	}
+
+	bundle := NewBundle([]map[string]interface{}{dashboard})
+
+	missing, err := bundle.MissingReferences()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []Ref{{Type: "index-pattern", ID: "missing-index"}}, missing)
+}
+
+func TestBundleGraph(t *testing.T) {
+	indexPattern := map[string]interface{}{"id": "index-1", "type": "index-pattern"}
+	visualization := map[string]interface{}{
+		"id":   "vis-1",
+		"type": "visualization",
+		"references": []interface{}{
+			map[string]interface{}{"id": "index-1", "type": "index-pattern", "name": "kibanaSavedObjectMeta.searchSourceJSON.index"},
+		},
+	}
+	dashboard := map[string]interface{}{
+		"id":   "dash-1",
+		"type": "dashboard",
+		"attributes": map[string]interface{}{
+			"panelsJSON": `[]`,
+		},
+		"references": []interface{}{
+			map[string]interface{}{"id": "vis-1", "type": "visualization", "name": "panel_0"},
+		},
+	}
+
+	bundle := NewBundle([]map[string]interface{}{indexPattern, visualization, dashboard})
+
+	graph, err := bundle.Graph()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []Ref{{Type: "visualization", ID: "vis-1"}}, graph[Ref{Type: "dashboard", ID: "dash-1"}])
+	assert.Equal(t, []Ref{{Type: "index-pattern", ID: "index-1"}}, graph[Ref{Type: "visualization", ID: "vis-1"}])
+	assert.Empty(t, graph[Ref{Type: "index-pattern", ID: "index-1"}])
+}
+
+func TestBundleMissingReferencesAcrossMultipleObjects(t *testing.T) {
+	visualization := map[string]interface{}{
+		"id":   "vis-1",
+		"type": "visualization",
+		"references": []interface{}{
+			map[string]interface{}{"id": "missing-index", "type": "index-pattern", "name": "kibanaSavedObjectMeta.searchSourceJSON.index"},
+		},
+	}
+	dashboard := map[string]interface{}{
+		"id":   "dash-1",
+		"type": "dashboard",
+		"attributes": map[string]interface{}{
+			"panelsJSON": `[]`,
+		},
+		"references": []interface{}{
+			map[string]interface{}{"id": "vis-1", "type": "visualization", "name": "panel_0"},
+			map[string]interface{}{"id": "missing-other-vis", "type": "visualization", "name": "panel_1"},
+		},
+	}
+
+	bundle := NewBundle([]map[string]interface{}{visualization, dashboard})
+
+	missing, err := bundle.MissingReferences()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.ElementsMatch(t, []Ref{
+		{Type: "index-pattern", ID: "missing-index"},
+		{Type: "visualization", ID: "missing-other-vis"},
+	}, missing)
+}
+
+func TestBundleTopologicalOrder(t *testing.T) {
+	indexPattern := map[string]interface{}{"id": "index-1", "type": "index-pattern"}
+	visualization := map[string]interface{}{
+		"id":   "vis-1",
+		"type": "visualization",
+		"references": []interface{}{
+			map[string]interface{}{"id": "index-1", "type": "index-pattern", "name": "kibanaSavedObjectMeta.searchSourceJSON.index"},
+		},
+	}
+	dashboard := map[string]interface{}{
+		"id":   "dash-1",
+		"type": "dashboard",
+		"attributes": map[string]interface{}{
+			"panelsJSON": `[]`,
+		},
+		"references": []interface{}{
+			map[string]interface{}{"id": "vis-1", "type": "visualization", "name": "panel_0"},
+		},
+	}
+
+	// Deliberately out of dependency order, to confirm TopologicalOrder
+	// actually reorders rather than passing inputs through unchanged.
+	bundle := NewBundle([]map[string]interface{}{dashboard, visualization, indexPattern})
+
+	order, err := bundle.TopologicalOrder()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, order, 3) {
+		assert.Equal(t, Ref{Type: "index-pattern", ID: "index-1"}, refOf(order[0]))
+		assert.Equal(t, Ref{Type: "visualization", ID: "vis-1"}, refOf(order[1]))
+		assert.Equal(t, Ref{Type: "dashboard", ID: "dash-1"}, refOf(order[2]))
+	}
+}
+
+func TestBundleTopologicalOrderDetectsCycle(t *testing.T) {
+	a := map[string]interface{}{
+		"id":   "a",
+		"type": "visualization",
+		"references": []interface{}{
+			map[string]interface{}{"id": "b", "type": "visualization", "name": "ref_0"},
+		},
+	}
+	b := map[string]interface{}{
+		"id":   "b",
+		"type": "visualization",
+		"references": []interface{}{
+			map[string]interface{}{"id": "a", "type": "visualization", "name": "ref_0"},
+		},
+	}
+
+	bundle := NewBundle([]map[string]interface{}{a, b})
+
+	_, err := bundle.TopologicalOrder()
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@
 package kbncontent
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -36,18 +37,25 @@ func (v VisualizationDescriptor) findDocumentPathsAsString(paths []string) strin
 	return ""
 }
 
-// Type returns the root-level visualization type
-// currently empty for Lens
+// Type returns the root-level visualization type.
+// For Lens, this is the raw `visualizationType` (lnsXY, lnsMetric, etc); see SemanticType
+// for a type vocabulary shared across editors.
 func (v VisualizationDescriptor) Type() string {
-	if v.SavedObjectType != "visualization" {
+	switch v.SavedObjectType {
+	case "visualization":
+		return v.findDocumentPathsAsString([]string{
+			"attributes.type",
+			"attributes.visState.type",
+			"embeddableConfig.savedVis.type", // by-value dashboard panel
+		})
+	case "lens":
+		return v.findDocumentPathsAsString([]string{
+			"attributes.visualizationType",
+			"embeddableConfig.attributes.visualizationType", // by-value dashboard panel
+		})
+	default:
 		return ""
 	}
-
-	return v.findDocumentPathsAsString([]string{
-		"attributes.type",
-		"attributes.visState.type",
-		"embeddableConfig.savedVis.type", // by-value dashboard panel
-	})
 }
 
 // Editor returns the name of the visualization editor
@@ -74,10 +82,20 @@ func (v VisualizationDescriptor) Editor() (string, error) {
 	return "", errors.New("Unknown editor type")
 }
 
+// legacyLensVisualizationTypes are Lens visualizationType values that Elastic
+// has designated legacy, even though they're still served through Lens itself.
+var legacyLensVisualizationTypes = map[string]bool{
+	"lnsLegacyMetric": true,
+}
+
 // IsLegacy returns whether the visualization is considered legacy
 // legacy visualizations should not be used and will be
 // removed from Kibana in the future
 func (v VisualizationDescriptor) IsLegacy() bool {
+	if v.SavedObjectType == "lens" {
+		return legacyLensVisualizationTypes[v.Type()]
+	}
+
 	if v.SavedObjectType != "visualization" {
 		return false
 	}
@@ -100,11 +118,61 @@ func (v VisualizationDescriptor) isTSVB() bool {
 	return v.Type() == "metrics"
 }
 
+// lensSemanticTypes maps Lens's `visualizationType` values to the same
+// vocabulary used for aggs-based and TSVB visualizations, for the chart types
+// whose `state.visualization` doesn't carry a more specific sub-type.
+var lensSemanticTypes = map[string]string{
+	"lnsXY":           "xy",
+	"lnsMetric":       "metric",
+	"lnsDatatable":    "table",
+	"lnsPie":          "pie",
+	"lnsHeatmap":      "heatmap",
+	"lnsLegacyMetric": "metric",
+}
+
+// lensVisualizationState returns a Lens saved object's `state.visualization`,
+// which carries chart-specific configuration - such as a pie's donut/treemap
+// shape, or an XY chart's preferred series type - that `visualizationType`
+// alone doesn't capture.
+func (v VisualizationDescriptor) lensVisualizationState() objx.Map {
+	if v.SavedObjectType != "lens" {
+		return nil
+	}
+
+	m := objx.Map(v.Doc)
+	statePaths := []string{
+		"attributes.state.visualization",
+		"embeddableConfig.attributes.state.visualization", // by-value dashboard panel
+	}
+	for _, path := range statePaths {
+		if state := m.Get(path); state.IsObjxMap() {
+			return state.ObjxMap()
+		}
+	}
+
+	return nil
+}
+
 // SemanticType is meant to be a visualization-editor-agnostic name for what
 // kind of visualization this actually is (pie, bar, etc)
-// Note: does not yet support Lens
 func (v VisualizationDescriptor) SemanticType() string {
-	if v.isTSVB() {
+	if v.SavedObjectType == "lens" {
+		state := v.lensVisualizationState()
+		switch v.Type() {
+		case "lnsPie":
+			if shape := state.Get("shape").Str(); shape != "" {
+				return shape // donut, treemap, mosaic, waffle, pie
+			}
+			return "pie"
+		case "lnsXY":
+			if seriesType := state.Get("preferredSeriesType").Str(); seriesType != "" {
+				return seriesType // bar, bar_horizontal, line, area, bar_stacked, ...
+			}
+			return "xy"
+		default:
+			return lensSemanticTypes[v.Type()]
+		}
+	} else if v.isTSVB() {
 		return v.TSVBType()
 	} else {
 		return v.Type()
@@ -160,7 +228,41 @@ func (v VisualizationDescriptor) HasFilters() (bool, error) {
 		}
 	}
 
-	return false, nil
+	return v.hasLensLayerFilters(m), nil
+}
+
+// hasLensLayerFilters returns true if any of a Lens visualization's per-layer
+// datasource states define their own filter or query, independent of the
+// visualization-level filters/query handled above.
+func (v VisualizationDescriptor) hasLensLayerFilters(m objx.Map) bool {
+	layersPaths := []string{
+		"attributes.state.datasourceStates.formBased.layers",
+		"embeddableConfig.attributes.state.datasourceStates.formBased.layers",
+	}
+	for _, path := range layersPaths {
+		layersValue := m.Get(path)
+		if !layersValue.IsMSI() {
+			continue
+		}
+
+		for _, rawLayer := range layersValue.MSI() {
+			layerMap, ok := rawLayer.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			layer := objx.Map(layerMap)
+
+			if query := layer.Get("query.query"); query.IsStr() && query.Str() != "" {
+				return true
+			}
+
+			if filters := layer.Get("filters"); filters.IsObjxMapSlice() && len(filters.ObjxMapSlice()) > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // TSVBType returns the TSVB sub type (gauge, markdown, etc)
@@ -207,34 +309,54 @@ func deserializeSubPaths(doc objx.Map) error {
 		doc.Set(fieldName, parsed)
 	}
 
-	/* these transformations from the original script facilitate the vis_tsvb_aggs and other TSVB-related runtime fields
-		TODO - implement these or convert the
-		vis_tsvb_aggs and any other necessary runtime fields to Go
-	if (
-	    doc?.attributes?.visState?.params?.filter &&
-	    typeof doc.attributes.visState.params.filter !== "string"
-	  ) {
-		  console.log("ENCOUNTERED STRINGIFIED visState.params.filter STATE", path)
-	    doc.attributes.visState.params.filter = JSON.stringify(
-	      doc.attributes.visState.params.filter
-	    );
-	  }
-	  if (
-	    doc?.attributes?.visState?.params?.series &&
-	    Array.isArray(doc.attributes.visState.params.series)
-	  ) {
-		  console.log("ENCOUNTERED STRINGIFIED visState.params.series STATE", path)
-	    doc.attributes.visState.params.series =
-	      doc.attributes.visState.params.series.map((s) => ({
-	        ...s,
-	        filter: JSON.stringify(s.filter),
-	      }));
-	  }
-	*/
+	// TSVB's `filter` fields are normally strings containing Lucene/KQL, but
+	// older visualizations sometimes persisted them as the raw filter object
+	// instead. Stringify those in place so every other reader of this state
+	// (TSVBAggs included) can assume `filter` is always a string.
+	if filter := doc.Get("attributes.visState.params.filter"); !filter.IsNil() && !filter.IsStr() {
+		encoded, err := stringifyValue(filter)
+		if err != nil {
+			return fmt.Errorf("failed to stringify visState.params.filter: %w", err)
+		}
+		doc.Set("attributes.visState.params.filter", encoded)
+	}
+
+	if series := doc.Get("attributes.visState.params.series"); series.IsObjxMapSlice() {
+		for _, s := range series.ObjxMapSlice() {
+			filter := s.Get("filter")
+			if filter.IsNil() || filter.IsStr() {
+				continue
+			}
+			encoded, err := stringifyValue(filter)
+			if err != nil {
+				return fmt.Errorf("failed to stringify series filter: %w", err)
+			}
+			s.Set("filter", encoded)
+		}
+	}
 
 	return nil
 }
 
+// stringifyValue JSON-encodes an objx.Value, used to normalize the handful of
+// TSVB fields that are sometimes persisted as an object instead of a string.
+// A nil value stringifies to "", and a value that's already a string passes
+// through unchanged rather than being double-encoded.
+func stringifyValue(val *objx.Value) (string, error) {
+	if val.IsNil() {
+		return "", nil
+	}
+	if val.IsStr() {
+		return val.Str(), nil
+	}
+
+	encoded, err := json.Marshal(val.Data())
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
 // DescribeVisualizationSavedObject reports information about a visualization saved object (unmarshalled JSON)
 // Supports maps, saved searches, Lens, Vega, and legacy visualizations
 func DescribeVisualizationSavedObject(doc map[string]interface{}) (VisualizationDescriptor, error) {
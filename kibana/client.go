@@ -0,0 +1,251 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package kibana implements a client for the subset of the Kibana Saved
+// Objects HTTP API needed to push and pull the content kbncontent understands
+// to and from a live Kibana instance.
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/kbncontent"
+)
+
+// Auth applies authentication to an outgoing request.
+type Auth interface {
+	apply(req *http.Request)
+}
+
+type basicAuth struct {
+	username, password string
+}
+
+// NewBasicAuth returns an Auth that authenticates with HTTP basic auth.
+func NewBasicAuth(username, password string) Auth {
+	return basicAuth{username: username, password: password}
+}
+
+func (a basicAuth) apply(req *http.Request) {
+	req.SetBasicAuth(a.username, a.password)
+}
+
+type apiKeyAuth struct {
+	apiKey string
+}
+
+// NewAPIKeyAuth returns an Auth that authenticates with a Kibana API key,
+// as returned by the `/api/security/api_key` endpoint.
+func NewAPIKeyAuth(apiKey string) Auth {
+	return apiKeyAuth{apiKey: apiKey}
+}
+
+func (a apiKeyAuth) apply(req *http.Request) {
+	req.Header.Set("Authorization", "ApiKey "+a.apiKey)
+}
+
+// Client is an HTTP client for the Kibana Saved Objects API.
+type Client struct {
+	BaseURL string
+	Auth    Auth
+	SpaceID string
+
+	// HTTPClient is used to make requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the Kibana instance at baseURL, authenticating
+// with auth and operating in the given space. If spaceID is empty, the default
+// space is used.
+func NewClient(baseURL string, auth Auth, spaceID string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Auth:       auth,
+		SpaceID:    spaceID,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+func (c *Client) path(p string) string {
+	if c.SpaceID == "" {
+		return c.BaseURL + p
+	}
+	return c.BaseURL + "/s/" + url.PathEscape(c.SpaceID) + p
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("kbn-xsrf", "true")
+	if c.Auth != nil {
+		c.Auth.apply(req)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL, err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("request to %s returned status %d: %s", req.URL, resp.StatusCode, body)
+	}
+
+	return resp, nil
+}
+
+// importResponse is the body of a Saved Objects _import response. A 2xx
+// status alone doesn't mean the import succeeded: Kibana reports per-object
+// failures (e.g. a conflicting ID, an unknown type) in the body with
+// success:false rather than a non-2xx status code.
+type importResponse struct {
+	Success      bool                     `json:"success"`
+	SuccessCount int                      `json:"successCount"`
+	Errors       []map[string]interface{} `json:"errors"`
+}
+
+// ImportDashboard imports a dashboard together with the saved objects that
+// make up its by-reference panels, via the Saved Objects import API. panels
+// must contain the VisualizationDescriptor for every ID returned by
+// GetByReferencePanelIDs(dashboard.Doc); it may safely contain more than
+// that (extras are ignored), such as when panels was gathered from
+// ExportDashboard's output via NewSavedObjectScanner.
+func (c *Client) ImportDashboard(ctx context.Context, dashboard kbncontent.VisualizationDescriptor, panels []kbncontent.VisualizationDescriptor) error {
+	panelIDs, err := kbncontent.GetByReferencePanelIDs(dashboard.Doc)
+	if err != nil {
+		return fmt.Errorf("failed to determine dashboard's by-reference panels: %w", err)
+	}
+
+	byID := make(map[string]kbncontent.VisualizationDescriptor, len(panels))
+	for _, panel := range panels {
+		if id, ok := panel.Doc["id"].(string); ok {
+			byID[id] = panel
+		}
+	}
+
+	var ndjson bytes.Buffer
+	enc := json.NewEncoder(&ndjson)
+	if err := enc.Encode(dashboard.Doc); err != nil {
+		return fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	for _, id := range panelIDs {
+		panel, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("missing saved object for by-reference panel %q", id)
+		}
+		if err := enc.Encode(panel.Doc); err != nil {
+			return fmt.Errorf("failed to encode panel %q: %w", id, err)
+		}
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "export.ndjson")
+	if err != nil {
+		return fmt.Errorf("failed to create import payload: %w", err)
+	}
+	if _, err := part.Write(ndjson.Bytes()); err != nil {
+		return fmt.Errorf("failed to write ndjson: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to finalize import payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.path("/api/saved_objects/_import?overwrite=true"), &body)
+	if err != nil {
+		return fmt.Errorf("failed to build import request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result importResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode import response: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("import failed: %v", result.Errors)
+	}
+
+	return nil
+}
+
+// ExportDashboard exports a dashboard and its references as NDJSON via the
+// Saved Objects export API. The returned reader yields NDJSON suitable for
+// feeding into kbncontent.NewSavedObjectScanner to recover the by-reference
+// panel VisualizationDescriptors ImportDashboard expects; the scanner yields a
+// dashboard's by-value panels, not a descriptor of the dashboard itself.
+func (c *Client) ExportDashboard(ctx context.Context, id string) (io.Reader, error) {
+	payload := fmt.Sprintf(`{"type":"dashboard","objects":[{"id":%q,"type":"dashboard"}],"includeReferencesDeep":true}`, id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.path("/api/saved_objects/_export"), strings.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to read export response: %w", err)
+	}
+
+	return &buf, nil
+}
+
+// FindResult is a page of results from FindByType.
+type FindResult struct {
+	Page         int                      `json:"page"`
+	PerPage      int                      `json:"per_page"`
+	Total        int                      `json:"total"`
+	SavedObjects []map[string]interface{} `json:"saved_objects"`
+}
+
+// FindByType returns a page of saved objects of the given type via the Saved
+// Objects find API. page is 1-indexed, matching the Kibana API.
+func (c *Client) FindByType(ctx context.Context, soType string, page int) (FindResult, error) {
+	query := url.Values{}
+	query.Set("type", soType)
+	query.Set("page", strconv.Itoa(page))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.path("/api/saved_objects/_find?"+query.Encode()), nil)
+	if err != nil {
+		return FindResult{}, fmt.Errorf("failed to build find request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return FindResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var result FindResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return FindResult{}, fmt.Errorf("failed to decode find response: %w", err)
+	}
+
+	return result, nil
+}
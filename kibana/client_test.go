@@ -0,0 +1,133 @@
+package kibana
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/kbncontent"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClient(url string) *Client {
+	return NewClient(url, NewBasicAuth("elastic", "changeme"), "")
+}
+
+func TestImportDashboardSendsDashboardAndByReferencePanels(t *testing.T) {
+	dashboard := kbncontent.VisualizationDescriptor{Doc: map[string]interface{}{
+		"id":   "dash-1",
+		"type": "dashboard",
+		"references": []interface{}{
+			map[string]interface{}{"id": "vis-1", "type": "visualization", "name": "panel_0"},
+		},
+	}}
+	panel := kbncontent.VisualizationDescriptor{Doc: map[string]interface{}{
+		"id":   "vis-1",
+		"type": "visualization",
+	}}
+
+	var importedIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/saved_objects/_import", r.URL.Path)
+
+		file, _, err := r.FormFile("file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var obj map[string]interface{}
+			if !assert.NoError(t, json.Unmarshal(scanner.Bytes(), &obj)) {
+				return
+			}
+			importedIDs = append(importedIDs, obj["id"].(string))
+		}
+
+		json.NewEncoder(w).Encode(importResponse{Success: true, SuccessCount: 2})
+	}))
+	defer server.Close()
+
+	err := testClient(server.URL).ImportDashboard(context.Background(), dashboard, []kbncontent.VisualizationDescriptor{panel})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dash-1", "vis-1"}, importedIDs)
+}
+
+func TestImportDashboardReturnsErrorOnPartialFailure(t *testing.T) {
+	dashboard := kbncontent.VisualizationDescriptor{Doc: map[string]interface{}{"id": "dash-1", "type": "dashboard", "references": []interface{}{}}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(importResponse{
+			Success: false,
+			Errors: []map[string]interface{}{
+				{"id": "dash-1", "type": "dashboard", "error": map[string]interface{}{"type": "conflict"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	err := testClient(server.URL).ImportDashboard(context.Background(), dashboard, nil)
+	assert.Error(t, err)
+}
+
+func TestImportDashboardReturnsErrorOnMissingPanel(t *testing.T) {
+	dashboard := kbncontent.VisualizationDescriptor{Doc: map[string]interface{}{
+		"id":   "dash-1",
+		"type": "dashboard",
+		"references": []interface{}{
+			map[string]interface{}{"id": "vis-1", "type": "visualization", "name": "panel_0"},
+		},
+	}}
+
+	err := testClient("http://example.invalid").ImportDashboard(context.Background(), dashboard, nil)
+	assert.Error(t, err)
+}
+
+func TestExportDashboard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/saved_objects/_export", r.URL.Path)
+		w.Write([]byte(`{"id":"dash-1","type":"dashboard","attributes":{"panelsJSON":"[]"}}` + "\n"))
+		w.Write([]byte(`{"id":"vis-1","type":"visualization","attributes":{"visState":"{\"type\":\"table\",\"params\":{}}"}}` + "\n"))
+	}))
+	defer server.Close()
+
+	reader, err := testClient(server.URL).ExportDashboard(context.Background(), "dash-1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scanner := kbncontent.NewSavedObjectScanner(reader, kbncontent.SkipUnknownSavedObjectTypes())
+	assert.True(t, scanner.Scan())
+	assert.Equal(t, "vis-1", scanner.Object().ID)
+	assert.NoError(t, scanner.Err())
+}
+
+func TestFindByType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/saved_objects/_find", r.URL.Path)
+		assert.Equal(t, "dashboard", r.URL.Query().Get("type"))
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+
+		json.NewEncoder(w).Encode(FindResult{
+			Page:         2,
+			PerPage:      20,
+			Total:        1,
+			SavedObjects: []map[string]interface{}{{"id": "dash-1", "type": "dashboard"}},
+		})
+	}))
+	defer server.Close()
+
+	result, err := testClient(server.URL).FindByType(context.Background(), "dashboard", 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, result.Page)
+	assert.Equal(t, 1, result.Total)
+	if assert.Len(t, result.SavedObjects, 1) {
+		assert.Equal(t, "dash-1", result.SavedObjects[0]["id"])
+	}
+}
@@ -0,0 +1,157 @@
+package kbncontent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lensDescriptor(t *testing.T, attributes map[string]interface{}) VisualizationDescriptor {
+	t.Helper()
+
+	doc := map[string]interface{}{
+		"type":       "lens",
+		"attributes": attributes,
+	}
+
+	desc, err := DescribeVisualizationSavedObject(doc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return desc
+}
+
+func TestLensIsLegacy(t *testing.T) {
+	legacyMetric := lensDescriptor(t, map[string]interface{}{"visualizationType": "lnsLegacyMetric"})
+	assert.True(t, legacyMetric.IsLegacy())
+
+	metric := lensDescriptor(t, map[string]interface{}{"visualizationType": "lnsMetric"})
+	assert.False(t, metric.IsLegacy())
+}
+
+func TestLensSemanticType(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]interface{}
+		expected   string
+	}{
+		{
+			name:       "pie with no shape set falls back to pie",
+			attributes: map[string]interface{}{"visualizationType": "lnsPie"},
+			expected:   "pie",
+		},
+		{
+			name: "pie with donut shape",
+			attributes: map[string]interface{}{
+				"visualizationType": "lnsPie",
+				"state":             map[string]interface{}{"visualization": map[string]interface{}{"shape": "donut"}},
+			},
+			expected: "donut",
+		},
+		{
+			name:       "xy with no preferred series type falls back to xy",
+			attributes: map[string]interface{}{"visualizationType": "lnsXY"},
+			expected:   "xy",
+		},
+		{
+			name: "xy with horizontal bar series type",
+			attributes: map[string]interface{}{
+				"visualizationType": "lnsXY",
+				"state":             map[string]interface{}{"visualization": map[string]interface{}{"preferredSeriesType": "bar_horizontal"}},
+			},
+			expected: "bar_horizontal",
+		},
+		{
+			name:       "metric",
+			attributes: map[string]interface{}{"visualizationType": "lnsMetric"},
+			expected:   "metric",
+		},
+		{
+			name:       "legacy metric maps to the same semantic type as metric",
+			attributes: map[string]interface{}{"visualizationType": "lnsLegacyMetric"},
+			expected:   "metric",
+		},
+		{
+			name:       "datatable",
+			attributes: map[string]interface{}{"visualizationType": "lnsDatatable"},
+			expected:   "table",
+		},
+		{
+			name:       "heatmap",
+			attributes: map[string]interface{}{"visualizationType": "lnsHeatmap"},
+			expected:   "heatmap",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desc := lensDescriptor(t, test.attributes)
+			assert.Equal(t, test.expected, desc.SemanticType())
+		})
+	}
+}
+
+func TestLensHasFiltersFromLayerState(t *testing.T) {
+	withLayerFilter := lensDescriptor(t, map[string]interface{}{
+		"visualizationType": "lnsXY",
+		"state": map[string]interface{}{
+			"query":   map[string]interface{}{"query": ""},
+			"filters": []interface{}{},
+			"datasourceStates": map[string]interface{}{
+				"formBased": map[string]interface{}{
+					"layers": map[string]interface{}{
+						"layer1": map[string]interface{}{
+							"filters": []interface{}{
+								map[string]interface{}{"meta": map[string]interface{}{}},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	hasFilters, err := withLayerFilter.HasFilters()
+	if assert.NoError(t, err) {
+		assert.True(t, hasFilters)
+	}
+
+	withLayerQuery := lensDescriptor(t, map[string]interface{}{
+		"visualizationType": "lnsXY",
+		"state": map[string]interface{}{
+			"query":   map[string]interface{}{"query": ""},
+			"filters": []interface{}{},
+			"datasourceStates": map[string]interface{}{
+				"formBased": map[string]interface{}{
+					"layers": map[string]interface{}{
+						"layer1": map[string]interface{}{
+							"query": map[string]interface{}{"query": "status:200"},
+						},
+					},
+				},
+			},
+		},
+	})
+	hasFilters, err = withLayerQuery.HasFilters()
+	if assert.NoError(t, err) {
+		assert.True(t, hasFilters)
+	}
+
+	withoutFilters := lensDescriptor(t, map[string]interface{}{
+		"visualizationType": "lnsXY",
+		"state": map[string]interface{}{
+			"query":   map[string]interface{}{"query": ""},
+			"filters": []interface{}{},
+			"datasourceStates": map[string]interface{}{
+				"formBased": map[string]interface{}{
+					"layers": map[string]interface{}{
+						"layer1": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	})
+	hasFilters, err = withoutFilters.HasFilters()
+	if assert.NoError(t, err) {
+		assert.False(t, hasFilters)
+	}
+}
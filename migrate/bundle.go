@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/kbncontent"
+)
+
+// MigrateAll rewrites dashboard's panelsJSON in place, replacing each legacy
+// by-value panel with its migrated equivalent and appending any references
+// the migration introduces to the dashboard's references[]. It returns the
+// combined warnings from every migration performed. By-reference panels, and
+// by-value panels that aren't legacy or have no registered Migrator, are left
+// untouched.
+func MigrateAll(dashboard map[string]interface{}) ([]string, error) {
+	attributes, ok := dashboard["attributes"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("dashboard attributes missing or of unexpected type")
+	}
+
+	panelsJSON, ok := attributes["panelsJSON"].(string)
+	if !ok {
+		return nil, errors.New("dashboard attributes.panelsJSON missing or of unexpected type")
+	}
+
+	var panels []map[string]interface{}
+	if err := json.Unmarshal([]byte(panelsJSON), &panels); err != nil {
+		return nil, fmt.Errorf("failed to parse panelsJSON: %w", err)
+	}
+
+	var warnings []string
+	var newReferences []interface{}
+	changed := false
+
+	for _, panel := range panels {
+		panelType, _ := panel["type"].(string)
+		if panelType == "" {
+			continue // by-reference panel, nothing embedded to migrate
+		}
+
+		desc := kbncontent.VisualizationDescriptor{Doc: panel, SavedObjectType: panelType, Link: "by_value"}
+		if !desc.IsLegacy() {
+			continue
+		}
+		if _, ok := registry[Key{SavedObjectType: desc.SavedObjectType, SemanticType: desc.SemanticType()}]; !ok {
+			continue
+		}
+
+		newDesc, panelWarnings, err := Migrate(desc)
+		if err != nil {
+			return warnings, fmt.Errorf("failed to migrate panel %q: %w", desc.Title(), err)
+		}
+		warnings = append(warnings, panelWarnings...)
+		changed = true
+
+		panel["type"] = newDesc.SavedObjectType
+		if embeddableConfig, ok := panel["embeddableConfig"].(map[string]interface{}); ok {
+			embeddableConfig["attributes"] = newDesc.Doc["attributes"]
+			delete(embeddableConfig, "savedVis")
+		}
+		if refs, ok := newDesc.Doc["references"].([]interface{}); ok {
+			newReferences = append(newReferences, refs...)
+		}
+	}
+
+	if !changed {
+		return warnings, nil
+	}
+
+	encoded, err := json.Marshal(panels)
+	if err != nil {
+		return warnings, fmt.Errorf("failed to re-encode panelsJSON: %w", err)
+	}
+	attributes["panelsJSON"] = string(encoded)
+
+	if len(newReferences) > 0 {
+		existingRefs, _ := dashboard["references"].([]interface{})
+		dashboard["references"] = append(existingRefs, newReferences...)
+	}
+
+	return warnings, nil
+}
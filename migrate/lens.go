@@ -0,0 +1,298 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/elastic/kbncontent"
+	"github.com/stretchr/objx"
+)
+
+func init() {
+	Register(Key{SavedObjectType: "visualization", SemanticType: "table"}, MigratorFunc(migrateTableToLens))
+	Register(Key{SavedObjectType: "visualization", SemanticType: "metric"}, MigratorFunc(migrateMetricToLens))
+	Register(Key{SavedObjectType: "visualization", SemanticType: "heatmap"}, MigratorFunc(migrateHeatmapToLens))
+
+	// Lens has no tag cloud chart, so tagcloud is migrated to a data table of
+	// the same terms and metric. Downstream users who have a better target in
+	// mind can override this by registering their own Migrator for
+	// Key{"visualization", "tagcloud"}.
+	Register(Key{SavedObjectType: "visualization", SemanticType: "tagcloud"}, MigratorFunc(migrateTagCloudToLens))
+}
+
+// sourceAgg is a normalized view of one entry in an aggs-based
+// visualization's `visState.aggs`.
+type sourceAgg struct {
+	ID     string
+	Type   string
+	Schema string
+	Field  string
+	Size   int
+}
+
+// sourceAggs reads and normalizes the aggregations configured on an
+// aggs-based visualization.
+func sourceAggs(desc kbncontent.VisualizationDescriptor) ([]sourceAgg, error) {
+	m := objx.Map(desc.Doc)
+
+	aggsValue := m.Get("attributes.visState.aggs")
+	if !aggsValue.IsObjxMapSlice() {
+		aggsValue = m.Get("embeddableConfig.savedVis.aggs") // by-value dashboard panel
+	}
+	if !aggsValue.IsObjxMapSlice() {
+		return nil, nil
+	}
+
+	aggs := make([]sourceAgg, 0, len(aggsValue.ObjxMapSlice()))
+	for _, agg := range aggsValue.ObjxMapSlice() {
+		aggs = append(aggs, sourceAgg{
+			ID:     agg.Get("id").Str(),
+			Type:   agg.Get("type").Str(),
+			Schema: agg.Get("schema").Str(),
+			Field:  agg.Get("params.field").Str(),
+			Size:   agg.Get("params.size").Int(5),
+		})
+	}
+	return aggs, nil
+}
+
+func firstAggWithSchema(aggs []sourceAgg, schema string) (sourceAgg, bool) {
+	for _, agg := range aggs {
+		if agg.Schema == schema {
+			return agg, true
+		}
+	}
+	return sourceAgg{}, false
+}
+
+// lensOperation maps an aggs-based agg type to the Lens operationType and
+// dataType that produce the same values.
+func lensOperation(agg sourceAgg) (operationType, dataType string) {
+	switch agg.Type {
+	case "count":
+		return "count", "number"
+	case "avg", "sum", "min", "max", "median":
+		return agg.Type, "number"
+	case "cardinality":
+		return "unique_count", "number"
+	case "terms":
+		return "terms", "string"
+	case "date_histogram":
+		return "date_histogram", "date"
+	case "histogram":
+		return "histogram", "number"
+	default:
+		return agg.Type, "number"
+	}
+}
+
+// lensColumn builds the Lens formBased column that reproduces agg.
+func lensColumn(agg sourceAgg) map[string]interface{} {
+	operationType, dataType := lensOperation(agg)
+
+	if operationType == "count" {
+		return map[string]interface{}{
+			"label":         "Count",
+			"dataType":      dataType,
+			"operationType": operationType,
+			"isBucketed":    false,
+			"sourceField":   "___records___",
+		}
+	}
+
+	column := map[string]interface{}{
+		"label":         fmt.Sprintf("%s of %s", operationType, agg.Field),
+		"dataType":      dataType,
+		"operationType": operationType,
+		"isBucketed":    agg.Schema != "metric",
+		"sourceField":   agg.Field,
+	}
+
+	switch operationType {
+	case "terms":
+		column["params"] = map[string]interface{}{
+			"size":           agg.Size,
+			"orderBy":        map[string]interface{}{"type": "alphabetical"},
+			"orderDirection": "asc",
+		}
+	case "date_histogram":
+		column["params"] = map[string]interface{}{"interval": "auto"}
+	}
+
+	return column
+}
+
+// buildLensLayer returns a single formBased layer containing one column per
+// agg, in the given order, along with the column IDs in that same order.
+func buildLensLayer(aggs []sourceAgg) (layer map[string]interface{}, columnOrder []string) {
+	columns := map[string]interface{}{}
+	for _, agg := range aggs {
+		columnID := "col-" + agg.ID
+		columns[columnID] = lensColumn(agg)
+		columnOrder = append(columnOrder, columnID)
+	}
+
+	return map[string]interface{}{
+		"columnOrder": columnOrder,
+		"columns":     columns,
+	}, columnOrder
+}
+
+// lensDoc assembles a Lens saved-object document around a single data layer
+// and its visualization-specific state.
+func lensDoc(title, visualizationType string, layer, visualization map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "lens",
+		"attributes": map[string]interface{}{
+			"title":             title,
+			"visualizationType": visualizationType,
+			"state": map[string]interface{}{
+				"visualization": visualization,
+				"datasourceStates": map[string]interface{}{
+					"formBased": map[string]interface{}{
+						"layers": map[string]interface{}{
+							"layer1": layer,
+						},
+					},
+				},
+				"query":   map[string]interface{}{"query": "", "language": "kuery"},
+				"filters": []interface{}{},
+			},
+		},
+		"references": []interface{}{},
+	}
+}
+
+func migrateTableToLens(desc kbncontent.VisualizationDescriptor) (map[string]interface{}, []string, error) {
+	aggs, err := sourceAggs(desc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(aggs) == 0 {
+		return nil, nil, errors.New("table visualization has no aggs to migrate")
+	}
+
+	layer, columnOrder := buildLensLayer(aggs)
+
+	columns := make([]map[string]interface{}, len(columnOrder))
+	for i, columnID := range columnOrder {
+		columns[i] = map[string]interface{}{"columnId": columnID, "isTransposed": false}
+	}
+
+	visualization := map[string]interface{}{
+		"layerId":   "layer1",
+		"layerType": "data",
+		"columns":   columns,
+	}
+
+	warnings := []string{
+		"column formatting, sorting, and pagination settings are not carried over and must be reconfigured by hand",
+	}
+
+	return lensDoc(desc.Title(), "lnsDatatable", layer, visualization), warnings, nil
+}
+
+func migrateMetricToLens(desc kbncontent.VisualizationDescriptor) (map[string]interface{}, []string, error) {
+	aggs, err := sourceAggs(desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metricAgg, ok := firstAggWithSchema(aggs, "metric")
+	if !ok {
+		return nil, nil, errors.New("metric visualization has no metric agg to migrate")
+	}
+
+	layer, columnOrder := buildLensLayer([]sourceAgg{metricAgg})
+
+	visualization := map[string]interface{}{
+		"layerId":   "layer1",
+		"layerType": "data",
+		"accessor":  columnOrder[0],
+	}
+
+	warnings := []string{
+		"color ranges are not carried over and must be reconfigured by hand",
+	}
+
+	return lensDoc(desc.Title(), "lnsMetric", layer, visualization), warnings, nil
+}
+
+func migrateTagCloudToLens(desc kbncontent.VisualizationDescriptor) (map[string]interface{}, []string, error) {
+	aggs, err := sourceAggs(desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucketAgg, ok := firstAggWithSchema(aggs, "segment")
+	if !ok {
+		return nil, nil, errors.New("tag cloud visualization has no bucket agg to migrate")
+	}
+	metricAgg, ok := firstAggWithSchema(aggs, "metric")
+	if !ok {
+		return nil, nil, errors.New("tag cloud visualization has no metric agg to migrate")
+	}
+
+	layer, columnOrder := buildLensLayer([]sourceAgg{bucketAgg, metricAgg})
+
+	columns := make([]map[string]interface{}, len(columnOrder))
+	for i, columnID := range columnOrder {
+		columns[i] = map[string]interface{}{"columnId": columnID, "isTransposed": false}
+	}
+
+	visualization := map[string]interface{}{
+		"layerId":   "layer1",
+		"layerType": "data",
+		"columns":   columns,
+	}
+
+	warnings := []string{
+		"Lens has no tag cloud chart; migrated to a data table of the same terms and metric - font scaling, orientation, and color settings are lost and must be reconfigured by hand",
+	}
+
+	return lensDoc(desc.Title(), "lnsDatatable", layer, visualization), warnings, nil
+}
+
+func migrateHeatmapToLens(desc kbncontent.VisualizationDescriptor) (map[string]interface{}, []string, error) {
+	aggs, err := sourceAggs(desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valueAgg, ok := firstAggWithSchema(aggs, "metric")
+	if !ok {
+		return nil, nil, errors.New("heatmap visualization has no metric agg to migrate")
+	}
+	xAgg, ok := firstAggWithSchema(aggs, "segment")
+	if !ok {
+		return nil, nil, errors.New("heatmap visualization has no X-axis bucket agg to migrate")
+	}
+
+	layerAggs := []sourceAgg{xAgg, valueAgg}
+	yAgg, hasY := firstAggWithSchema(aggs, "group")
+	if hasY {
+		layerAggs = append(layerAggs, yAgg)
+	}
+
+	layer, columnOrder := buildLensLayer(layerAggs)
+
+	visualization := map[string]interface{}{
+		"layerId":       "layer1",
+		"layerType":     "data",
+		"xAccessor":     columnOrder[0],
+		"valueAccessor": columnOrder[1],
+	}
+	if hasY {
+		visualization["yAccessor"] = columnOrder[2]
+	}
+
+	warnings := []string{
+		"the color palette and stops are not carried over and must be reconfigured by hand",
+	}
+
+	return lensDoc(desc.Title(), "lnsHeatmap", layer, visualization), warnings, nil
+}
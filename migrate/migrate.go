@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package migrate upgrades legacy Kibana visualizations, as flagged by
+// kbncontent's VisualizationDescriptor.IsLegacy, to their modern replacement
+// editor.
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/elastic/kbncontent"
+)
+
+// Key identifies a Migrator by the saved-object type and semantic type it
+// knows how to upgrade, e.g. {"visualization", "heatmap"}.
+type Key struct {
+	SavedObjectType string
+	SemanticType    string
+}
+
+// Migrator upgrades a single legacy visualization to its modern replacement,
+// returning the new saved-object document and any warnings about fidelity
+// lost in the conversion.
+type Migrator interface {
+	Migrate(desc kbncontent.VisualizationDescriptor) (newDoc map[string]interface{}, warnings []string, err error)
+}
+
+// MigratorFunc adapts a plain function to a Migrator.
+type MigratorFunc func(desc kbncontent.VisualizationDescriptor) (map[string]interface{}, []string, error)
+
+// Migrate calls f.
+func (f MigratorFunc) Migrate(desc kbncontent.VisualizationDescriptor) (map[string]interface{}, []string, error) {
+	return f(desc)
+}
+
+var registry = map[Key]Migrator{}
+
+// Register adds a Migrator for the given Key, overwriting any existing
+// registration for that Key. This is the extension point for downstream
+// users who want to support converters beyond the built-in set.
+func Register(key Key, m Migrator) {
+	registry[key] = m
+}
+
+// Migrate upgrades desc to its modern replacement using the Migrator
+// registered for its (SavedObjectType, SemanticType). It returns an error if
+// desc isn't legacy, or if no Migrator is registered for it.
+func Migrate(desc kbncontent.VisualizationDescriptor) (kbncontent.VisualizationDescriptor, []string, error) {
+	if !desc.IsLegacy() {
+		return kbncontent.VisualizationDescriptor{}, nil, fmt.Errorf("%q is not legacy, nothing to migrate", desc.Title())
+	}
+
+	key := Key{SavedObjectType: desc.SavedObjectType, SemanticType: desc.SemanticType()}
+	migrator, ok := registry[key]
+	if !ok {
+		return kbncontent.VisualizationDescriptor{}, nil, fmt.Errorf("no migrator registered for %s %q", key.SavedObjectType, key.SemanticType)
+	}
+
+	newDoc, warnings, err := migrator.Migrate(desc)
+	if err != nil {
+		return kbncontent.VisualizationDescriptor{}, warnings, fmt.Errorf("failed to migrate %q: %w", desc.Title(), err)
+	}
+
+	newDesc, err := kbncontent.DescribeVisualizationSavedObject(newDoc)
+	if err != nil {
+		return kbncontent.VisualizationDescriptor{}, warnings, fmt.Errorf("migrated document for %q is invalid: %w", desc.Title(), err)
+	}
+
+	return newDesc, warnings, nil
+}
@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/elastic/kbncontent"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func legacyDescriptor(t *testing.T, title, visState string) kbncontent.VisualizationDescriptor {
+	t.Helper()
+
+	doc := map[string]interface{}{
+		"type": "visualization",
+		"attributes": map[string]interface{}{
+			"title":    title,
+			"visState": visState,
+		},
+	}
+
+	desc, err := kbncontent.DescribeVisualizationSavedObject(doc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return desc
+}
+
+func layerColumns(t *testing.T, doc map[string]interface{}) objx.Map {
+	t.Helper()
+	return objx.Map(doc).Get("attributes.state.datasourceStates.formBased.layers.layer1.columns").ObjxMap()
+}
+
+func TestMigrateTableToLens(t *testing.T) {
+	desc := legacyDescriptor(t, "My table", `{
+		"type": "table",
+		"params": {},
+		"aggs": [
+			{"id": "1", "enabled": true, "type": "count", "schema": "metric", "params": {}},
+			{"id": "2", "enabled": true, "type": "terms", "schema": "bucket", "params": {"field": "geo.src", "size": 5}}
+		]
+	}`)
+	assert.True(t, desc.IsLegacy())
+
+	newDesc, warnings, err := Migrate(desc)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "lens", newDesc.SavedObjectType)
+	assert.Equal(t, "lnsDatatable", newDesc.Type())
+	assert.NotEmpty(t, warnings)
+
+	columns := layerColumns(t, newDesc.Doc)
+	if assert.Len(t, columns, 2) {
+		count := objx.Map(columns["col-1"].(map[string]interface{}))
+		assert.Equal(t, "count", count.Get("operationType").Str())
+		assert.Equal(t, "___records___", count.Get("sourceField").Str())
+
+		terms := objx.Map(columns["col-2"].(map[string]interface{}))
+		assert.Equal(t, "terms", terms.Get("operationType").Str())
+		assert.Equal(t, "geo.src", terms.Get("sourceField").Str())
+		assert.Equal(t, 5, terms.Get("params.size").Int())
+	}
+}
+
+func TestMigrateMetricToLens(t *testing.T) {
+	desc := legacyDescriptor(t, "My metric", `{
+		"type": "metric",
+		"params": {},
+		"aggs": [
+			{"id": "1", "enabled": true, "type": "avg", "schema": "metric", "params": {"field": "bytes"}}
+		]
+	}`)
+	assert.True(t, desc.IsLegacy())
+
+	newDesc, _, err := Migrate(desc)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "lnsMetric", newDesc.Type())
+
+	columns := layerColumns(t, newDesc.Doc)
+	if assert.Len(t, columns, 1) {
+		avg := objx.Map(columns["col-1"].(map[string]interface{}))
+		assert.Equal(t, "avg", avg.Get("operationType").Str())
+		assert.Equal(t, "bytes", avg.Get("sourceField").Str())
+	}
+
+	accessor := objx.Map(newDesc.Doc).Get("attributes.state.visualization.accessor").Str()
+	assert.Equal(t, "col-1", accessor)
+}
+
+func TestMigrateTagCloudToLens(t *testing.T) {
+	desc := legacyDescriptor(t, "My tag cloud", `{
+		"type": "tagcloud",
+		"params": {},
+		"aggs": [
+			{"id": "1", "enabled": true, "type": "terms", "schema": "segment", "params": {"field": "geo.src", "size": 10}},
+			{"id": "2", "enabled": true, "type": "count", "schema": "metric", "params": {}}
+		]
+	}`)
+	assert.True(t, desc.IsLegacy())
+
+	newDesc, warnings, err := Migrate(desc)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "lnsDatatable", newDesc.Type())
+	assert.NotEmpty(t, warnings)
+
+	columns := layerColumns(t, newDesc.Doc)
+	if assert.Len(t, columns, 2) {
+		terms := objx.Map(columns["col-1"].(map[string]interface{}))
+		assert.Equal(t, "terms", terms.Get("operationType").Str())
+		assert.Equal(t, "geo.src", terms.Get("sourceField").Str())
+
+		count := objx.Map(columns["col-2"].(map[string]interface{}))
+		assert.Equal(t, "count", count.Get("operationType").Str())
+	}
+}
+
+func TestMigrateTagCloudToLensRequiresBucketAgg(t *testing.T) {
+	desc := legacyDescriptor(t, "My tag cloud", `{
+		"type": "tagcloud",
+		"params": {},
+		"aggs": [
+			{"id": "1", "enabled": true, "type": "count", "schema": "metric", "params": {}}
+		]
+	}`)
+
+	_, _, err := Migrate(desc)
+	assert.Error(t, err, "a tag cloud with no bucket agg can't be migrated")
+}
+
+func TestMigrateHeatmapToLensRequiresMetricAndSegmentAggs(t *testing.T) {
+	desc := legacyDescriptor(t, "My heatmap", `{
+		"type": "heatmap",
+		"params": {},
+		"aggs": [
+			{"id": "1", "enabled": true, "type": "count", "schema": "metric", "params": {}}
+		]
+	}`)
+
+	_, _, err := Migrate(desc)
+	assert.Error(t, err, "a heatmap with no X-axis bucket agg can't be migrated")
+}
+
+func TestMigrateNonLegacyReturnsError(t *testing.T) {
+	desc := legacyDescriptor(t, "My markdown", `{"type":"markdown","params":{}}`)
+	assert.False(t, desc.IsLegacy())
+
+	_, _, err := Migrate(desc)
+	assert.Error(t, err)
+}
+
+func TestMigrateAllRewritesPanelsJSON(t *testing.T) {
+	dashboard := map[string]interface{}{
+		"type": "dashboard",
+		"attributes": map[string]interface{}{
+			"panelsJSON": `[{"type":"visualization","embeddableConfig":{"savedVis":{"title":"My table","type":"table","params":{},"aggs":[{"id":"1","enabled":true,"type":"count","schema":"metric","params":{}}]}}}]`,
+		},
+	}
+
+	warnings, err := MigrateAll(dashboard)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, warnings)
+
+	panels, err := kbncontent.DescribeByValueDashboardPanels(dashboard)
+	if assert.NoError(t, err) && assert.Len(t, panels, 1) {
+		assert.Equal(t, "lens", panels[0].SavedObjectType)
+	}
+}
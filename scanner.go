@@ -0,0 +1,277 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kbncontent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// knownSavedObjectTypes are the saved-object types DescribeVisualizationSavedObject
+// can describe directly. Dashboards are handled separately, via their
+// by-value panels; anything else is unsupported.
+var knownSavedObjectTypes = map[string]bool{
+	"visualization": true,
+	"lens":          true,
+	"map":           true,
+	"search":        true,
+}
+
+// ScannedObject pairs a VisualizationDescriptor with metadata about the
+// saved object it was decoded from.
+type ScannedObject struct {
+	Descriptor VisualizationDescriptor
+	ID         string
+	Type       string
+	Line       int
+}
+
+// ScannerOption configures a Scanner returned by NewSavedObjectScanner.
+type ScannerOption func(*Scanner)
+
+// SkipUnknownSavedObjectTypes configures the Scanner to silently skip saved
+// objects whose type isn't supported by DescribeVisualizationSavedObject,
+// instead of failing the scan.
+func SkipUnknownSavedObjectTypes() ScannerOption {
+	return func(s *Scanner) { s.skipUnknown = true }
+}
+
+// WithScannerWorkers sets the number of goroutines used to decode saved
+// objects concurrently. The default is 1, which decodes one object at a time
+// on a single worker goroutine, with no parallelism.
+func WithScannerWorkers(n int) ScannerOption {
+	return func(s *Scanner) {
+		if n > 0 {
+			s.workers = n
+		}
+	}
+}
+
+type scanJob struct {
+	line    []byte
+	lineNum int
+	result  chan scanResult
+}
+
+type scanResult struct {
+	objs []ScannedObject
+	err  error
+}
+
+// Scanner reads one JSON saved object per line from an NDJSON export, such as
+// the ones produced by the Saved Objects `_export` API, and decodes them into
+// VisualizationDescriptors without requiring the caller to buffer the whole
+// export in memory. Construct one with NewSavedObjectScanner.
+//
+// Scanner follows the same usage pattern as bufio.Scanner: call Scan in a
+// loop, and read the current object with Object/Descriptor in between calls.
+// If the caller stops calling Scan before it returns false - for example,
+// breaking out of the loop early once it's found what it needs in a large
+// export - it must call Close, or the reader and decode-worker goroutines
+// backing the Scanner will block forever and leak.
+type Scanner struct {
+	lines       *bufio.Scanner
+	skipUnknown bool
+	workers     int
+
+	started bool
+	results chan scanResult
+	quit    chan struct{}
+
+	pending []ScannedObject
+	current ScannedObject
+	err     error
+}
+
+// NewSavedObjectScanner returns a Scanner over r, which must contain one JSON
+// saved object per line.
+func NewSavedObjectScanner(r io.Reader, opts ...ScannerOption) *Scanner {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	s := &Scanner{lines: lines, workers: 1, quit: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Scan advances the Scanner to the next VisualizationDescriptor, which will
+// then be available through Descriptor/Object. It returns false when the
+// scan stops, either by reaching the end of the input or encountering an
+// error, which can then be retrieved with Err.
+func (s *Scanner) Scan() bool {
+	if !s.started {
+		s.start()
+		s.started = true
+	}
+
+	for len(s.pending) == 0 {
+		res, ok := <-s.results
+		if !ok {
+			return false
+		}
+		if res.err != nil {
+			s.err = res.err
+			s.stop()
+			return false
+		}
+		s.pending = res.objs
+	}
+
+	s.current, s.pending = s.pending[0], s.pending[1:]
+	return true
+}
+
+// Descriptor returns the VisualizationDescriptor found by the most recent
+// call to Scan.
+func (s *Scanner) Descriptor() VisualizationDescriptor {
+	return s.current.Descriptor
+}
+
+// Object returns the full ScannedObject, including source metadata, found by
+// the most recent call to Scan.
+func (s *Scanner) Object() ScannedObject {
+	return s.current
+}
+
+// Err returns the first non-EOF error encountered while scanning, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Close stops the Scanner's background goroutines. It is idempotent and
+// always returns nil. Callers only need it when abandoning a Scanner before
+// Scan has returned false on its own; a scan run to completion already shuts
+// its goroutines down.
+func (s *Scanner) Close() error {
+	s.stop()
+	return nil
+}
+
+// start launches the read/decode/collect pipeline: one goroutine reads lines
+// and fans them out to a bounded pool of decode workers, and a collector
+// re-assembles their results in the original line order.
+func (s *Scanner) start() {
+	jobs := make(chan scanJob, s.workers)
+	order := make(chan chan scanResult, s.workers)
+	s.results = make(chan scanResult, s.workers)
+
+	go func() {
+		defer close(jobs)
+		defer close(order)
+
+		lineNum := 0
+		for s.lines.Scan() {
+			lineNum++
+			line := append([]byte(nil), s.lines.Bytes()...)
+			result := make(chan scanResult, 1)
+
+			select {
+			case order <- result:
+			case <-s.quit:
+				return
+			}
+			select {
+			case jobs <- scanJob{line: line, lineNum: lineNum, result: result}:
+			case <-s.quit:
+				return
+			}
+		}
+
+		// s.lines is only ever touched by this goroutine, so reading its error
+		// here - rather than letting Err() read it from the caller's goroutine
+		// after Scan returns false - is what keeps that read race-free.
+		if err := s.lines.Err(); err != nil {
+			result := make(chan scanResult, 1)
+			result <- scanResult{err: fmt.Errorf("failed to read input: %w", err)}
+			select {
+			case order <- result:
+			case <-s.quit:
+			}
+		}
+	}()
+
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			for j := range jobs {
+				objs, err := s.decode(j.line, j.lineNum)
+				j.result <- scanResult{objs: objs, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(s.results)
+		for result := range order {
+			select {
+			case r := <-result:
+				select {
+				case s.results <- r:
+				case <-s.quit:
+					return
+				}
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Scanner) stop() {
+	select {
+	case <-s.quit:
+	default:
+		close(s.quit)
+	}
+}
+
+// decode parses a single NDJSON line and describes the saved object(s) it
+// contains. A dashboard line yields its by-value panels, one ScannedObject
+// per panel; every other supported type yields exactly one.
+func (s *Scanner) decode(line []byte, lineNum int) ([]ScannedObject, error) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(line, &doc); err != nil {
+		return nil, fmt.Errorf("line %d: failed to parse JSON: %w", lineNum, err)
+	}
+
+	id, _ := doc["id"].(string)
+	soType, _ := doc["type"].(string)
+
+	if soType == "dashboard" {
+		panels, err := DescribeByValueDashboardPanels(doc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: failed to describe dashboard panels: %w", lineNum, err)
+		}
+
+		objs := make([]ScannedObject, len(panels))
+		for i, panel := range panels {
+			objs[i] = ScannedObject{Descriptor: panel, ID: id, Type: soType, Line: lineNum}
+		}
+		return objs, nil
+	}
+
+	if !knownSavedObjectTypes[soType] {
+		if s.skipUnknown {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("line %d: unsupported saved object type %q", lineNum, soType)
+	}
+
+	desc, err := DescribeVisualizationSavedObject(doc)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", lineNum, err)
+	}
+
+	return []ScannedObject{{Descriptor: desc, ID: id, Type: soType, Line: lineNum}}, nil
+}
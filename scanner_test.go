@@ -0,0 +1,72 @@
+package kbncontent
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedObjectScannerMixedTypes(t *testing.T) {
+	ndjson := strings.Join([]string{
+		`{"id":"vis-1","type":"visualization","attributes":{"title":"My table","visState":"{\"type\":\"table\",\"params\":{}}"}}`,
+		`{"id":"dash-1","type":"dashboard","attributes":{"panelsJSON":"[{\"type\":\"lens\",\"embeddableConfig\":{\"attributes\":{\"visualizationType\":\"lnsMetric\"}}}]"}}`,
+		`{"id":"unsupported-1","type":"config","attributes":{}}`,
+	}, "\n")
+
+	scanner := NewSavedObjectScanner(strings.NewReader(ndjson), SkipUnknownSavedObjectTypes(), WithScannerWorkers(2))
+
+	var objects []ScannedObject
+	for scanner.Scan() {
+		objects = append(objects, scanner.Object())
+	}
+
+	if !assert.NoError(t, scanner.Err()) {
+		return
+	}
+
+	if assert.Len(t, objects, 2) {
+		assert.Equal(t, "vis-1", objects[0].ID)
+		assert.Equal(t, 1, objects[0].Line)
+		assert.Equal(t, "table", objects[0].Descriptor.Type())
+
+		assert.Equal(t, "dash-1", objects[1].ID)
+		assert.Equal(t, 2, objects[1].Line)
+		assert.Equal(t, "lens", objects[1].Descriptor.SavedObjectType)
+	}
+}
+
+func TestSavedObjectScannerErrorsOnUnsupportedType(t *testing.T) {
+	ndjson := `{"id":"unsupported-1","type":"config","attributes":{}}`
+
+	scanner := NewSavedObjectScanner(strings.NewReader(ndjson))
+
+	assert.False(t, scanner.Scan())
+	assert.Error(t, scanner.Err())
+}
+
+func TestSavedObjectScannerCloseStopsGoroutines(t *testing.T) {
+	line := `{"id":"vis-1","type":"visualization","attributes":{"title":"My table","visState":"{\"type\":\"table\",\"params\":{}}"}}`
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = line
+	}
+	ndjson := strings.Join(lines, "\n")
+
+	before := runtime.NumGoroutine()
+
+	scanner := NewSavedObjectScanner(strings.NewReader(ndjson), WithScannerWorkers(4))
+	assert.True(t, scanner.Scan())
+	assert.NoError(t, scanner.Close())
+
+	after := before + 1
+	for i := 0; i < 100 && after > before; i++ {
+		runtime.Gosched()
+		time.Sleep(5 * time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+
+	assert.LessOrEqual(t, after, before, "Close should let the scanner's reader/worker/collector goroutines exit instead of leaking")
+}
@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kbncontent
+
+import (
+	"fmt"
+
+	"github.com/stretchr/objx"
+)
+
+// TSVBMetric is a single metric within a TSVB series.
+type TSVBMetric struct {
+	Type   string
+	Field  string
+	Script string
+}
+
+// TSVBAgg is one flattened TSVB aggregation: a single metric plus the filters
+// scoping the series it belongs to. A series with multiple stacked metrics
+// (e.g. a bucket script on top of an average) produces one TSVBAgg per metric.
+type TSVBAgg struct {
+	SeriesID     string
+	Filter       string
+	SplitFilters string
+	Metric       TSVBMetric
+}
+
+// TSVBAggs walks a TSVB visualization's series, normalizes their metrics and
+// filters, and returns a flat slice usable for telemetry/inventory. It
+// returns nil if the visualization is not TSVB.
+func (v VisualizationDescriptor) TSVBAggs() ([]TSVBAgg, error) {
+	if !v.isTSVB() {
+		return nil, nil
+	}
+
+	m := objx.Map(v.Doc)
+	if err := deserializeSubPaths(m); err != nil {
+		return nil, fmt.Errorf("failed to deserialize embedded JSON objects: %w", err)
+	}
+
+	seriesValue := m.Get("attributes.visState.params.series")
+	if !seriesValue.IsObjxMapSlice() {
+		seriesValue = m.Get("embeddableConfig.savedVis.params.series") // by-value dashboard panel
+	}
+	if !seriesValue.IsObjxMapSlice() {
+		return nil, nil
+	}
+
+	var aggs []TSVBAgg
+	for _, series := range seriesValue.ObjxMapSlice() {
+		seriesID := series.Get("id").Str()
+
+		filter, err := stringifyValue(series.Get("filter"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to stringify filter for series %q: %w", seriesID, err)
+		}
+
+		splitFilters, err := stringifyValue(series.Get("split_filters"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to stringify split_filters for series %q: %w", seriesID, err)
+		}
+
+		metricsValue := series.Get("metrics")
+		if !metricsValue.IsObjxMapSlice() {
+			continue
+		}
+
+		for _, metric := range metricsValue.ObjxMapSlice() {
+			aggs = append(aggs, TSVBAgg{
+				SeriesID:     seriesID,
+				Filter:       filter,
+				SplitFilters: splitFilters,
+				Metric: TSVBMetric{
+					Type:   metric.Get("type").Str(),
+					Field:  metric.Get("field").Str(),
+					Script: metric.Get("script").Str(),
+				},
+			})
+		}
+	}
+
+	return aggs, nil
+}
+
+// RuntimeFields returns the set of scripted metric fields referenced by a
+// dashboard's TSVB panels, aggregated across every panel so callers can build
+// a Kibana-style runtime-field mapping for the index patterns it queries.
+func RuntimeFields(descriptors []VisualizationDescriptor) ([]string, error) {
+	seen := make(map[string]bool)
+	var fields []string
+
+	for _, desc := range descriptors {
+		aggs, err := desc.TSVBAggs()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, agg := range aggs {
+			if agg.Metric.Script == "" || agg.Metric.Field == "" {
+				continue
+			}
+			if seen[agg.Metric.Field] {
+				continue
+			}
+			seen[agg.Metric.Field] = true
+			fields = append(fields, agg.Metric.Field)
+		}
+	}
+
+	return fields, nil
+}
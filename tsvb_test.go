@@ -0,0 +1,128 @@
+package kbncontent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tsvbDescriptor(t *testing.T, visState string) VisualizationDescriptor {
+	t.Helper()
+
+	doc := map[string]interface{}{
+		"type": "visualization",
+		"attributes": map[string]interface{}{
+			"visState": visState,
+		},
+	}
+
+	desc, err := DescribeVisualizationSavedObject(doc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return desc
+}
+
+func TestTSVBAggsStringFilter(t *testing.T) {
+	desc := tsvbDescriptor(t, `{
+		"type": "metrics",
+		"params": {
+			"series": [
+				{"id": "series-1", "filter": "status:200", "metrics": [{"type": "avg", "field": "bytes"}]}
+			]
+		}
+	}`)
+
+	aggs, err := desc.TSVBAggs()
+	if assert.NoError(t, err) && assert.Len(t, aggs, 1) {
+		assert.Equal(t, "series-1", aggs[0].SeriesID)
+		assert.Equal(t, "status:200", aggs[0].Filter)
+		assert.Equal(t, TSVBMetric{Type: "avg", Field: "bytes"}, aggs[0].Metric)
+	}
+}
+
+func TestTSVBAggsObjectFilterAndMultiMetricStack(t *testing.T) {
+	desc := tsvbDescriptor(t, `{
+		"type": "metrics",
+		"params": {
+			"series": [
+				{
+					"id": "series-1",
+					"filter": {"language": "kuery", "query": "status:200"},
+					"split_filters": [{"filter": {"language": "kuery", "query": "geo:us"}, "label": "US"}],
+					"metrics": [
+						{"type": "avg", "field": "bytes", "id": "metric-0"},
+						{"type": "bucket_script", "field": "metric-0", "script": "params.avg / 1024"}
+					]
+				}
+			]
+		}
+	}`)
+
+	aggs, err := desc.TSVBAggs()
+	if !assert.NoError(t, err) || !assert.Len(t, aggs, 2) {
+		return
+	}
+
+	assert.JSONEq(t, `{"language":"kuery","query":"status:200"}`, aggs[0].Filter)
+	assert.JSONEq(t, `[{"filter":{"language":"kuery","query":"geo:us"},"label":"US"}]`, aggs[0].SplitFilters)
+	assert.Equal(t, "avg", aggs[0].Metric.Type)
+	assert.Equal(t, "bucket_script", aggs[1].Metric.Type)
+	assert.Equal(t, "params.avg / 1024", aggs[1].Metric.Script)
+}
+
+func TestTSVBAggsByValueDashboardPanel(t *testing.T) {
+	doc := map[string]interface{}{
+		"type": "visualization",
+		"embeddableConfig": map[string]interface{}{
+			"savedVis": map[string]interface{}{
+				"type": "metrics",
+				"params": map[string]interface{}{
+					"series": []interface{}{
+						map[string]interface{}{
+							"id":      "series-1",
+							"filter":  "status:200",
+							"metrics": []interface{}{map[string]interface{}{"type": "avg", "field": "bytes"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	desc, err := DescribeVisualizationSavedObject(doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "metrics", desc.Type())
+
+	aggs, err := desc.TSVBAggs()
+	if assert.NoError(t, err) && assert.Len(t, aggs, 1) {
+		assert.Equal(t, "series-1", aggs[0].SeriesID)
+		assert.Equal(t, "status:200", aggs[0].Filter)
+		assert.Equal(t, TSVBMetric{Type: "avg", Field: "bytes"}, aggs[0].Metric)
+	}
+}
+
+func TestRuntimeFields(t *testing.T) {
+	desc := tsvbDescriptor(t, `{
+		"type": "metrics",
+		"params": {
+			"series": [
+				{
+					"id": "series-1",
+					"metrics": [
+						{"type": "avg", "field": "bytes"},
+						{"type": "bucket_script", "field": "normalized_bytes", "script": "params.avg / 1024"}
+					]
+				}
+			]
+		}
+	}`)
+
+	fields, err := RuntimeFields([]VisualizationDescriptor{desc})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"normalized_bytes"}, fields)
+	}
+}